@@ -0,0 +1,109 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// colorHueBins, colorSatBins and colorValBins are the histogram
+// resolution ColorHash uses for each HSV channel; hue gets more bins
+// since it dominates perceived color similarity.
+const (
+	colorHueBins = 8
+	colorSatBins = 4
+	colorValBins = 4
+)
+
+// ColorHash converts img to HSV and builds a coarse histogram over
+// hue/saturation/value bins. Each bin's fractional share of the image
+// is quantized into binbits bits, so two images with similar color
+// distributions hash close together even when their structural content
+// differs (unlike AHash/DHash/PHash/WHash).
+func ColorHash(img image.Image, binbits int) (*ExtImageHash, error) {
+	bounds := img.Bounds()
+	total := float64(bounds.Dx()) * float64(bounds.Dy())
+	if total == 0 {
+		return nil, errors.New("goimagehash: image has no pixels")
+	}
+
+	counts := make([]float64, colorHueBins+colorSatBins+colorValBins)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			h, s, v := rgbToHSV(img.At(x, y))
+			counts[colorBin(h, colorHueBins)]++
+			counts[colorHueBins+colorBin(s, colorSatBins)]++
+			counts[colorHueBins+colorSatBins+colorBin(v, colorValBins)]++
+		}
+	}
+
+	bits := len(counts) * binbits
+	words := make([]uint64, (bits+63)/64)
+	maxCode := uint64(1)<<uint(binbits) - 1
+	pos := 0
+	for _, count := range counts {
+		code := uint64((count / total) * float64(maxCode))
+		if code > maxCode {
+			code = maxCode
+		}
+		for i := 0; i < binbits; i++ {
+			if code&(1<<uint(i)) != 0 {
+				words[pos/64] |= 1 << uint(pos%64)
+			}
+			pos++
+		}
+	}
+
+	return NewExtImageHash(words, CHash, bits), nil
+}
+
+// colorBin maps a fraction in [0, 1] into one of n equal-width bins;
+// a fraction of exactly 1 falls into the last bin.
+func colorBin(frac float64, n int) int {
+	bin := int(frac * float64(n))
+	if bin >= n {
+		bin = n - 1
+	}
+	if bin < 0 {
+		bin = 0
+	}
+	return bin
+}
+
+// rgbToHSV returns c's hue normalized to [0, 1) and its saturation and
+// value normalized to [0, 1].
+func rgbToHSV(c color.Color) (h, s, v float64) {
+	r32, g32, b32, _ := c.RGBA()
+	r, g, b := float64(r32)/0xffff, float64(g32)/0xffff, float64(b32)/0xffff
+
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	v = max
+	if max > 0 {
+		s = delta / max
+	}
+	if delta == 0 {
+		return 0, s, v
+	}
+
+	switch max {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h /= 6
+	if h < 0 {
+		h++
+	}
+	return h, s, v
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+// Kind identifies the perceptual hash algorithm that produced an
+// ImageHash or ExtImageHash.
+type Kind uint8
+
+const (
+	// Unknown is the zero Kind, used for hashes not produced by one of
+	// the named constructors below.
+	Unknown Kind = iota
+	// AHash identifies a hash produced by AverageHash / ExtAverageHash.
+	AHash
+	// PHash identifies a hash produced by PerceptionHash / ExtPerceptionHash.
+	PHash
+	// DHash identifies a hash produced by DifferenceHash / ExtDifferenceHash.
+	DHash
+	// WHash identifies a hash produced by WaveletHash / ExtWaveletHash.
+	WHash
+	// CHash identifies a hash produced by ColorHash.
+	CHash
+)
+
+// kindCodes is the single source of truth for the one-character code
+// each Kind is written as by ToString/Dump and read back as by
+// ImageHashFromString/ExtImageHashFromString/Load.
+var kindCodes = map[Kind]byte{
+	Unknown: 'u',
+	AHash:   'a',
+	PHash:   'p',
+	DHash:   'd',
+	WHash:   'w',
+	CHash:   'c',
+}
+
+var codeKinds = func() map[byte]Kind {
+	m := make(map[byte]Kind, len(kindCodes))
+	for kind, code := range kindCodes {
+		m[code] = kind
+	}
+	return m
+}()
+
+// String returns k's one-character code, as used by ToString.
+func (k Kind) String() string {
+	code, ok := kindCodes[k]
+	if !ok {
+		return "?"
+	}
+	return string(code)
+}
+
+// kindFromCode looks up the Kind for a one-character code produced by
+// Kind.String(), for use by ImageHashFromString/ExtImageHashFromString.
+func kindFromCode(code byte) (Kind, bool) {
+	kind, ok := codeKinds[code]
+	return kind, ok
+}
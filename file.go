@@ -0,0 +1,174 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"errors"
+	"image"
+	"io"
+	"os"
+	"sync"
+)
+
+var errUnsupportedMethod = errors.New("goimagehash: unsupported hash method")
+
+// decodeFunc is the decoder HashFile and HashPath use to turn a reader
+// into an image.Image. It defaults to a thin wrapper around
+// image.Decode (which only knows the formats registered with
+// image.RegisterFormat, typically PNG, GIF and JPEG via their blank
+// imports, and also returns the detected format name we don't need
+// here); RegisterDecoder swaps it out.
+var (
+	decodeMu   sync.RWMutex
+	decodeFunc = defaultDecode
+)
+
+func defaultDecode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// RegisterDecoder overrides the function HashFile and HashPath use to
+// decode a reader into an image.Image, so that callers can plug in
+// formats such as HEIC, WebP or AVIF without goimagehash importing
+// those codecs directly:
+//
+//	import "golang.org/x/image/webp"
+//
+//	goimagehash.RegisterDecoder(func(r io.Reader) (image.Image, error) {
+//		return webp.Decode(r)
+//	})
+func RegisterDecoder(decode func(r io.Reader) (image.Image, error)) {
+	decodeMu.Lock()
+	defer decodeMu.Unlock()
+	decodeFunc = decode
+}
+
+func decodeImage(r io.Reader) (image.Image, error) {
+	decodeMu.RLock()
+	defer decodeMu.RUnlock()
+	return decodeFunc(r)
+}
+
+// hasherFor looks up the *ImageHash constructor for method.
+func hasherFor(method Kind) (func(image.Image) (*ImageHash, error), error) {
+	switch method {
+	case AHash:
+		return AverageHash, nil
+	case PHash:
+		return PerceptionHash, nil
+	case DHash:
+		return DifferenceHash, nil
+	case WHash:
+		return WaveletHash, nil
+	default:
+		return nil, errUnsupportedMethod
+	}
+}
+
+// extHasherFor looks up the *ExtImageHash constructor for method.
+func extHasherFor(method Kind) (func(image.Image, int, int) (*ExtImageHash, error), error) {
+	switch method {
+	case AHash:
+		return ExtAverageHash, nil
+	case PHash:
+		return ExtPerceptionHash, nil
+	case DHash:
+		return ExtDifferenceHash, nil
+	case WHash:
+		return ExtWaveletHash, nil
+	default:
+		return nil, errUnsupportedMethod
+	}
+}
+
+// HashFile decodes the image read from r and hashes it with method,
+// saving callers the usual image.Decode-then-hash boilerplate.
+func HashFile(r io.Reader, method Kind) (*ImageHash, error) {
+	hasher, err := hasherFor(method)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+	return hasher(img)
+}
+
+// ExtHashFile is like HashFile but produces a w x h ExtImageHash.
+func ExtHashFile(r io.Reader, method Kind, w, h int) (*ExtImageHash, error) {
+	hasher, err := extHasherFor(method)
+	if err != nil {
+		return nil, err
+	}
+	img, err := decodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+	return hasher(img, w, h)
+}
+
+// HashPath opens the file at path and hashes it with method.
+func HashPath(path string, method Kind) (*ImageHash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return HashFile(f, method)
+}
+
+// ExtHashPath is like HashPath but produces a w x h ExtImageHash.
+func ExtHashPath(path string, method Kind, w, h int) (*ExtImageHash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ExtHashFile(f, method, w, h)
+}
+
+// BatchResult is one entry of HashBatch's output: the hash computed
+// for Path, or Err if opening, decoding or hashing it failed.
+type BatchResult struct {
+	Path string
+	Hash *ImageHash
+	Err  error
+}
+
+// HashBatch hashes every path in paths with method, fanning the work
+// out across workers goroutines. Results are returned in the same
+// order as paths regardless of which goroutine finishes first, so
+// callers can zip Path/Hash/Err back up with their own bookkeeping
+// (e.g. before feeding them into an index.DB or a Store).
+func HashBatch(paths []string, method Kind, workers int) []BatchResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]BatchResult, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				hash, err := HashPath(paths[idx], method)
+				results[idx] = BatchResult{Path: paths[idx], Hash: hash, Err: err}
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestHashFileAndHashPath(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	examples := []string{
+		"_examples/sample1.jpg", "_examples/sample2.jpg", "_examples/sample3.jpg", "_examples/sample4.jpg",
+	}
+
+	for _, ex := range examples {
+		hash, err := HashPath(ex, AHash)
+		checkErr(err)
+
+		want, err := AverageHash(mustDecode(t, ex))
+		checkErr(err)
+
+		distance, err := hash.Distance(want)
+		checkErr(err)
+		if distance != 0 {
+			t.Errorf("HashPath(%v) differs from AverageHash, distance=%v", ex, distance)
+		}
+
+		extHash, err := ExtHashPath(ex, PHash, 16, 16)
+		checkErr(err)
+		if extHash.Bits() != 256 {
+			t.Errorf("ExtHashPath(%v) bits = %v, want 256", ex, extHash.Bits())
+		}
+	}
+
+	if _, err := HashPath("_examples/sample1.jpg", Unknown); err == nil {
+		t.Error("HashPath with an unsupported method should have failed")
+	}
+}
+
+func mustDecode(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("os.Open(%v): %v", path, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("image.Decode(%v): %v", path, err)
+	}
+	return img
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	orig := decodeFunc
+	defer func() { decodeFunc = orig }()
+
+	var gotBytes []byte
+	RegisterDecoder(func(r io.Reader) (image.Image, error) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		gotBytes = b
+		return image.NewGray(image.Rect(0, 0, 8, 8)), nil
+	})
+
+	hash, err := HashFile(bytes.NewReader([]byte("not a real image")), AHash)
+	if err != nil {
+		t.Fatalf("HashFile: %v", err)
+	}
+	if hash == nil {
+		t.Fatal("expected a hash from the registered decoder")
+	}
+	if string(gotBytes) != "not a real image" {
+		t.Errorf("registered decoder saw %q, want %q", gotBytes, "not a real image")
+	}
+}
+
+func TestHashBatchOrder(t *testing.T) {
+	paths := []string{"_examples/sample1.jpg", "_examples/missing.jpg", "_examples/sample2.jpg"}
+	results := HashBatch(paths, AHash, 2)
+
+	if len(results) != len(paths) {
+		t.Fatalf("HashBatch returned %d results, want %d", len(results), len(paths))
+	}
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("result %d Path = %q, want %q", i, r.Path, paths[i])
+		}
+	}
+	if results[1].Err == nil {
+		t.Error("expected an error hashing a nonexistent file")
+	}
+}
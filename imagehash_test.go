@@ -75,10 +75,10 @@ func TestSerialization(t *testing.T) {
 	}
 
 	methods := []func(img image.Image) (*ImageHash, error){
-		AverageHash, PerceptionHash, DifferenceHash,
+		AverageHash, PerceptionHash, DifferenceHash, WaveletHash,
 	}
 	extMethods := []func(img image.Image, width int, height int) (*ExtImageHash, error){
-		ExtAverageHash, ExtPerceptionHash, ExtDifferenceHash,
+		ExtAverageHash, ExtPerceptionHash, ExtDifferenceHash, ExtWaveletHash,
 	}
 	examples := []string{
 		"_examples/sample1.jpg", "_examples/sample2.jpg", "_examples/sample3.jpg", "_examples/sample4.jpg",
@@ -141,6 +141,25 @@ func TestSerialization(t *testing.T) {
 				}
 			}
 		}
+
+		// ColorHash returns an *ExtImageHash but is keyed by a bin
+		// count rather than a width/height, so it can't share the
+		// extMethods loop above; exercise its ToString/FromString
+		// round trip here instead of opening ex a second time.
+		for _, binbits := range []int{2, 4} {
+			hash, err := ColorHash(img, binbits)
+			checkErr(err)
+
+			hex := hash.ToString()
+			reHash, err := ExtImageHashFromString(hex)
+			checkErr(err)
+
+			distance, err := hash.Distance(reHash)
+			checkErr(err)
+			if distance != 0 {
+				t.Errorf("Original and unserialized ColorHash should be identical, got distance=%v; binbits=%v of '%v'", distance, binbits, ex)
+			}
+		}
 	}
 
 	// test for hashing empty string
@@ -188,6 +207,34 @@ func TestDifferentBitSizeHash(t *testing.T) {
 		t.Errorf("Should got error with different bits of hashes")
 	}
 }
+
+// TestExtWaveletHashNonSquare guards against a panic in
+// waveletCoefficients for dimensions that aren't both square and a
+// power of two: sample non-square and non-power-of-two w/h pairs.
+func TestExtWaveletHashNonSquare(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	file, err := os.Open("_examples/sample1.jpg")
+	checkErr(err)
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	checkErr(err)
+
+	dims := [][2]int{{16, 8}, {10, 10}, {4, 8}}
+	for _, dim := range dims {
+		w, h := dim[0], dim[1]
+		hash, err := ExtWaveletHash(img, w, h)
+		checkErr(err)
+		if hash.Bits() != w*h {
+			t.Errorf("ExtWaveletHash(%d, %d) bits = %v, want %v", w, h, hash.Bits(), w*h)
+		}
+	}
+}
 func TestDumpAndLoad(t *testing.T) {
 	checkErr := func(err error) {
 		if err != nil {
@@ -196,7 +243,7 @@ func TestDumpAndLoad(t *testing.T) {
 	}
 
 	methods := []func(img image.Image) (*ImageHash, error){
-		AverageHash, PerceptionHash, DifferenceHash,
+		AverageHash, PerceptionHash, DifferenceHash, WaveletHash,
 	}
 	examples := []string{
 		"_examples/sample1.jpg", "_examples/sample2.jpg", "_examples/sample3.jpg", "_examples/sample4.jpg",
@@ -237,7 +284,7 @@ func TestDumpAndLoad(t *testing.T) {
 
 		// test for ExtIExtImageHash
 		extMethods := []func(img image.Image, width, height int) (*ExtImageHash, error){
-			ExtAverageHash, ExtPerceptionHash, ExtDifferenceHash,
+			ExtAverageHash, ExtPerceptionHash, ExtDifferenceHash, ExtWaveletHash,
 		}
 
 		sizeList := []int{8, 16}
@@ -266,6 +313,28 @@ func TestDumpAndLoad(t *testing.T) {
 				}
 			}
 		}
+
+		// ColorHash doesn't fit the extMethods loop above (it's keyed
+		// by a bin count, not a width/height), so it gets its own
+		// small Dump/Load round trip here instead of a separate test.
+		for _, binbits := range []int{2, 4} {
+			hash, err := ColorHash(img, binbits)
+			checkErr(err)
+			var b bytes.Buffer
+			foo := bufio.NewWriter(&b)
+			err = hash.Dump(foo)
+			checkErr(err)
+			foo.Flush()
+			bar := bufio.NewReader(&b)
+			reHash, err := LoadExtImageHash(bar)
+			checkErr(err)
+
+			distance, err := hash.Distance(reHash)
+			checkErr(err)
+			if distance != 0 {
+				t.Errorf("Original and unserialized ColorHash should be identical, got distance=%v; binbits=%v", distance, binbits)
+			}
+		}
 	}
 
 	// test for loading empty bytes buffer
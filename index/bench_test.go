@@ -0,0 +1,56 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index_test
+
+import (
+	"testing"
+
+	"github.com/waitscm/goimagehash"
+	"github.com/waitscm/goimagehash/index"
+)
+
+func buildHashes(n int) []*goimagehash.ImageHash {
+	hashes := make([]*goimagehash.ImageHash, n)
+	for i := range hashes {
+		// A cheap hash mix so the corpus isn't all clustered near 0.
+		hashes[i] = goimagehash.NewImageHash(uint64(i)*2654435761, goimagehash.AHash)
+	}
+	return hashes
+}
+
+// BenchmarkDBSearch measures a BK-tree lookup against a 10k-hash DB.
+func BenchmarkDBSearch(b *testing.B) {
+	hashes := buildHashes(10000)
+	db := index.New()
+	for _, h := range hashes {
+		if _, err := db.Add(h); err != nil {
+			b.Fatal(err)
+		}
+	}
+	query := hashes[0]
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.Search(query, 4); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLinearSearch measures the naive alternative to BenchmarkDBSearch:
+// calling Distance against every stored hash in turn.
+func BenchmarkLinearSearch(b *testing.B) {
+	hashes := buildHashes(10000)
+	query := hashes[0]
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, h := range hashes {
+			if _, err := query.Distance(h); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
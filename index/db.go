@@ -0,0 +1,150 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"sort"
+
+	"github.com/waitscm/goimagehash"
+)
+
+// node is a single BK-tree node. children is keyed on the Hamming
+// distance from node's hash to the child's hash.
+type node struct {
+	id       ID
+	hash     *goimagehash.ImageHash
+	children map[int]*node
+}
+
+// DB is a searchable collection of *goimagehash.ImageHash values, all
+// of the same Kind and bit size, indexed with a BK-tree for
+// sub-linear nearest-neighbor search. The zero value is not usable;
+// call New instead.
+type DB struct {
+	root   *node
+	hashes map[ID]*goimagehash.ImageHash
+	kind   goimagehash.Kind
+	bits   int
+	nextID ID
+}
+
+// New returns an empty DB.
+func New() *DB {
+	return &DB{hashes: make(map[ID]*goimagehash.ImageHash)}
+}
+
+// Add inserts hash into the DB and returns the ID it was assigned. The
+// first hash added fixes the DB's Kind and bit size; every hash added
+// afterwards must match both.
+func (db *DB) Add(hash *goimagehash.ImageHash) (ID, error) {
+	if hash == nil {
+		return 0, errHashRequired
+	}
+	if len(db.hashes) == 0 {
+		db.kind = hash.GetKind()
+		db.bits = hash.Bits()
+	} else if hash.GetKind() != db.kind {
+		return 0, errKindMismatch
+	} else if hash.Bits() != db.bits {
+		return 0, errBitsMismatch
+	}
+
+	id := db.nextID
+	db.nextID++
+	db.hashes[id] = hash
+
+	n := &node{id: id, hash: hash}
+	if db.root == nil {
+		db.root = n
+		return id, nil
+	}
+
+	cur := db.root
+	for {
+		d, err := cur.hash.Distance(hash)
+		if err != nil {
+			return 0, err
+		}
+		if cur.children == nil {
+			cur.children = make(map[int]*node)
+		}
+		child, ok := cur.children[d]
+		if !ok {
+			cur.children[d] = n
+			return id, nil
+		}
+		cur = child
+	}
+}
+
+// Delete removes the hash stored under id from lookups. The BK-tree
+// node it occupies is left in place, since discarding it would require
+// re-inserting its whole subtree; Search and Each simply skip it.
+func (db *DB) Delete(id ID) error {
+	if _, ok := db.hashes[id]; !ok {
+		return errNotFound
+	}
+	delete(db.hashes, id)
+	return nil
+}
+
+// Len returns the number of hashes currently stored in the DB.
+func (db *DB) Len() int {
+	return len(db.hashes)
+}
+
+// Each calls fn once for every hash currently stored in the DB, in no
+// particular order. Iteration stops early if fn returns false.
+func (db *DB) Each(fn func(id ID, hash *goimagehash.ImageHash) bool) {
+	for id, hash := range db.hashes {
+		if !fn(id, hash) {
+			return
+		}
+	}
+}
+
+// Search returns every stored hash within maxHamming of query, sorted
+// by increasing Hamming distance.
+func (db *DB) Search(query *goimagehash.ImageHash, maxHamming int) ([]Match, error) {
+	if query == nil {
+		return nil, errHashRequired
+	}
+	if db.root == nil {
+		return nil, nil
+	}
+
+	var matches []Match
+	var visit func(n *node)
+	visit = func(n *node) {
+		d, err := n.hash.Distance(query)
+		if err != nil {
+			return
+		}
+		if _, live := db.hashes[n.id]; live && d <= maxHamming {
+			matches = append(matches, Match{ID: n.id, Distance: d})
+		}
+
+		// Triangle inequality: any match under a child reached by an
+		// edge of distance e must have |e-d| <= maxHamming, so only
+		// children with e in [d-maxHamming, d+maxHamming] can hold one.
+		// The lower bound clamps to 0, not 1: an edge of distance 0
+		// (an exact duplicate inserted under this node) is a valid
+		// child and must stay reachable at every radius.
+		lo := d - maxHamming
+		if lo < 0 {
+			lo = 0
+		}
+		hi := d + maxHamming
+		for edge, child := range n.children {
+			if edge >= lo && edge <= hi {
+				visit(child)
+			}
+		}
+	}
+	visit(db.root)
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	return matches, nil
+}
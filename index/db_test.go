@@ -0,0 +1,118 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index_test
+
+import (
+	"testing"
+
+	"github.com/waitscm/goimagehash"
+	"github.com/waitscm/goimagehash/index"
+)
+
+func TestDBSearch(t *testing.T) {
+	db := index.New()
+
+	hashes := []uint64{0x0, 0x1, 0x3, 0xff, 0xf0f0f0f0f0f0f0f0}
+	ids := make([]index.ID, len(hashes))
+	for i, h := range hashes {
+		id, err := db.Add(goimagehash.NewImageHash(h, goimagehash.AHash))
+		if err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+		ids[i] = id
+	}
+
+	if db.Len() != len(hashes) {
+		t.Fatalf("Len() = %d, want %d", db.Len(), len(hashes))
+	}
+
+	query := goimagehash.NewImageHash(0x0, goimagehash.AHash)
+	matches, err := db.Search(query, 2)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	// 0x0 (0 bits set), 0x1 (1 bit), 0x3 (2 bits) are within radius 2.
+	if len(matches) != 3 {
+		t.Fatalf("Search returned %d matches, want 3: %+v", len(matches), matches)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i-1].Distance > matches[i].Distance {
+			t.Fatalf("matches not sorted by distance: %+v", matches)
+		}
+	}
+
+	if err := db.Delete(ids[0]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if db.Len() != len(hashes)-1 {
+		t.Fatalf("Len() after Delete = %d, want %d", db.Len(), len(hashes)-1)
+	}
+	if matches, err := db.Search(query, 0); err != nil || len(matches) != 0 {
+		t.Fatalf("Search after Delete = %+v, %v; want no matches", matches, err)
+	}
+
+	if _, err := db.Add(goimagehash.NewImageHash(0x0, goimagehash.PHash)); err == nil {
+		t.Fatal("Add with mismatched Kind should have failed")
+	}
+}
+
+// TestDBSearchFindsExactDuplicate guards against a BK-tree off-by-one:
+// a second hash landing at Hamming distance 0 from a node already in
+// the tree is stored as that node's edge-0 child, and must stay
+// reachable by Search at every radius, including 0.
+func TestDBSearchFindsExactDuplicate(t *testing.T) {
+	db := index.New()
+
+	original := goimagehash.NewImageHash(0x0f0f0f0f0f0f0f0f, goimagehash.AHash)
+	if _, err := db.Add(original); err != nil {
+		t.Fatalf("Add(original): %v", err)
+	}
+	dupID, err := db.Add(goimagehash.NewImageHash(0x0f0f0f0f0f0f0f0f, goimagehash.AHash))
+	if err != nil {
+		t.Fatalf("Add(duplicate): %v", err)
+	}
+
+	matches, err := db.Search(original, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	var found bool
+	for _, m := range matches {
+		if m.ID == dupID {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Search(radius=0) = %+v, missing exact duplicate id %v", matches, dupID)
+	}
+}
+
+func TestDBEach(t *testing.T) {
+	db := index.New()
+	want := map[index.ID]bool{}
+	for _, h := range []uint64{1, 2, 3} {
+		id, err := db.Add(goimagehash.NewImageHash(h, goimagehash.AHash))
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		want[id] = true
+	}
+
+	got := map[index.ID]bool{}
+	db.Each(func(id index.ID, hash *goimagehash.ImageHash) bool {
+		got[id] = true
+		return true
+	})
+
+	if len(got) != len(want) {
+		t.Fatalf("Each visited %d hashes, want %d", len(got), len(want))
+	}
+	for id := range want {
+		if !got[id] {
+			t.Errorf("Each did not visit id %v", id)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"sort"
+
+	"github.com/waitscm/goimagehash"
+)
+
+// extNode is a BK-tree node over *goimagehash.ExtImageHash, keyed on
+// Hamming distance the same way node is for the fixed-width DB.
+type extNode struct {
+	id       ID
+	hash     *goimagehash.ExtImageHash
+	children map[int]*extNode
+}
+
+// ExtDB is the *goimagehash.ExtImageHash counterpart of DB: a BK-tree
+// indexed collection of variable-width hashes, all sharing the same
+// Kind and bit size. Hamming distance between entries is delegated to
+// ExtImageHash.Distance, which compares the underlying []uint64 blocks.
+type ExtDB struct {
+	root   *extNode
+	hashes map[ID]*goimagehash.ExtImageHash
+	kind   goimagehash.Kind
+	bits   int
+	nextID ID
+}
+
+// NewExt returns an empty ExtDB.
+func NewExt() *ExtDB {
+	return &ExtDB{hashes: make(map[ID]*goimagehash.ExtImageHash)}
+}
+
+// Add inserts hash into the ExtDB and returns the ID it was assigned.
+// The first hash added fixes the ExtDB's Kind and bit size; every hash
+// added afterwards must match both.
+func (db *ExtDB) Add(hash *goimagehash.ExtImageHash) (ID, error) {
+	if hash == nil {
+		return 0, errHashRequired
+	}
+	if len(db.hashes) == 0 {
+		db.kind = hash.GetKind()
+		db.bits = hash.Bits()
+	} else if hash.GetKind() != db.kind {
+		return 0, errKindMismatch
+	} else if hash.Bits() != db.bits {
+		return 0, errBitsMismatch
+	}
+
+	id := db.nextID
+	db.nextID++
+	db.hashes[id] = hash
+
+	n := &extNode{id: id, hash: hash}
+	if db.root == nil {
+		db.root = n
+		return id, nil
+	}
+
+	cur := db.root
+	for {
+		d, err := cur.hash.Distance(hash)
+		if err != nil {
+			return 0, err
+		}
+		if cur.children == nil {
+			cur.children = make(map[int]*extNode)
+		}
+		child, ok := cur.children[d]
+		if !ok {
+			cur.children[d] = n
+			return id, nil
+		}
+		cur = child
+	}
+}
+
+// Delete removes the hash stored under id from lookups.
+func (db *ExtDB) Delete(id ID) error {
+	if _, ok := db.hashes[id]; !ok {
+		return errNotFound
+	}
+	delete(db.hashes, id)
+	return nil
+}
+
+// Len returns the number of hashes currently stored in the ExtDB.
+func (db *ExtDB) Len() int {
+	return len(db.hashes)
+}
+
+// Each calls fn once for every hash currently stored in the ExtDB, in
+// no particular order. Iteration stops early if fn returns false.
+func (db *ExtDB) Each(fn func(id ID, hash *goimagehash.ExtImageHash) bool) {
+	for id, hash := range db.hashes {
+		if !fn(id, hash) {
+			return
+		}
+	}
+}
+
+// Search returns every stored hash within maxHamming of query, sorted
+// by increasing Hamming distance.
+func (db *ExtDB) Search(query *goimagehash.ExtImageHash, maxHamming int) ([]Match, error) {
+	if query == nil {
+		return nil, errHashRequired
+	}
+	if db.root == nil {
+		return nil, nil
+	}
+
+	var matches []Match
+	var visit func(n *extNode)
+	visit = func(n *extNode) {
+		d, err := n.hash.Distance(query)
+		if err != nil {
+			return
+		}
+		if _, live := db.hashes[n.id]; live && d <= maxHamming {
+			matches = append(matches, Match{ID: n.id, Distance: d})
+		}
+
+		// See the matching comment in db.go: the lower bound clamps to
+		// 0, not 1, so an exact-duplicate child (edge distance 0)
+		// stays reachable at every radius.
+		lo := d - maxHamming
+		if lo < 0 {
+			lo = 0
+		}
+		hi := d + maxHamming
+		for edge, child := range n.children {
+			if edge >= lo && edge <= hi {
+				visit(child)
+			}
+		}
+	}
+	visit(db.root)
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	return matches, nil
+}
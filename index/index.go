@@ -0,0 +1,28 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package index provides an in-memory searchable collection of image
+// hashes. Hashes are indexed with a BK-tree so that nearest-neighbor
+// lookups run in sub-linear time instead of comparing the query against
+// every stored hash with goimagehash.ImageHash.Distance directly.
+package index
+
+import "errors"
+
+// ID identifies a hash that has been added to a DB or ExtDB.
+type ID uint64
+
+var (
+	errHashRequired = errors.New("index: hash must not be nil")
+	errKindMismatch = errors.New("index: hash kind does not match the index")
+	errBitsMismatch = errors.New("index: hash bit size does not match the index")
+	errNotFound     = errors.New("index: id not found")
+)
+
+// Match is a single search result: the ID a hash was assigned by Add,
+// paired with its Hamming distance from the query.
+type Match struct {
+	ID       ID
+	Distance int
+}
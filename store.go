@@ -0,0 +1,307 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// storeMagic and storeVersion identify the file format written by
+// Writer and Store.Save, and checked by Reader and Store.Load.
+const (
+	storeMagic   uint32 = 0x676f6948 // "goiH"
+	storeVersion uint16 = 1
+)
+
+// Record type tags let Reader tell a fixed-width ImageHash record
+// apart from a variable-width ExtImageHash one; both interleave freely
+// in the same file.
+const (
+	recordImageHash uint8 = iota
+	recordExtImageHash
+)
+
+var (
+	errStoreBadMagic   = errors.New("goimagehash: not a Store file")
+	errStoreBadVersion = errors.New("goimagehash: unsupported Store format version")
+	errEntryEmpty      = errors.New("goimagehash: entry has no hash")
+)
+
+// Entry is a single record read from or written to a Store: exactly
+// one of Hash or ExtHash is set, plus arbitrary caller-supplied
+// Metadata (e.g. a source filename or database ID) that travels
+// alongside the hash.
+type Entry struct {
+	Hash     *ImageHash
+	ExtHash  *ExtImageHash
+	Metadata []byte
+}
+
+// Writer appends Entry records to an underlying io.Writer one at a
+// time, so that large collections of hashes can be persisted without
+// buffering them all in memory first.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer that appends records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteHeader writes the Store file header. Call it once, before any
+// records, when starting a new file; omit it when appending to an
+// existing Store file.
+func (w *Writer) WriteHeader() error {
+	if err := binary.Write(w.w, binary.BigEndian, storeMagic); err != nil {
+		return err
+	}
+	return binary.Write(w.w, binary.BigEndian, storeVersion)
+}
+
+// Write appends a single Entry record.
+func (w *Writer) Write(e Entry) error {
+	var tag uint8
+	var kind Kind
+	var raw []byte
+	var bits uint32
+	switch {
+	case e.Hash != nil:
+		tag = recordImageHash
+		kind, raw = e.Hash.ByteArr()
+		bits = uint32(e.Hash.Bits())
+	case e.ExtHash != nil:
+		tag = recordExtImageHash
+		kind, raw = e.ExtHash.ByteArr()
+		bits = uint32(e.ExtHash.Bits())
+	default:
+		return errEntryEmpty
+	}
+
+	fields := []interface{}{tag, uint8(kind), bits, uint32(len(e.Metadata))}
+	for _, f := range fields {
+		if err := binary.Write(w.w, binary.BigEndian, f); err != nil {
+			return err
+		}
+	}
+	if len(e.Metadata) > 0 {
+		if _, err := w.w.Write(e.Metadata); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(w.w, binary.BigEndian, uint32(len(raw))); err != nil {
+		return err
+	}
+	_, err := w.w.Write(raw)
+	return err
+}
+
+// Reader reads back Entry records written by Writer, one at a time,
+// without loading the whole Store into memory.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader over r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadHeader validates the Store file header. Call it once before the
+// first call to Read.
+func (r *Reader) ReadHeader() error {
+	var magic uint32
+	if err := binary.Read(r.r, binary.BigEndian, &magic); err != nil {
+		return err
+	}
+	if magic != storeMagic {
+		return errStoreBadMagic
+	}
+	var version uint16
+	if err := binary.Read(r.r, binary.BigEndian, &version); err != nil {
+		return err
+	}
+	if version != storeVersion {
+		return errStoreBadVersion
+	}
+	return nil
+}
+
+// Read returns the next Entry, or io.EOF once the Store is exhausted.
+func (r *Reader) Read() (Entry, error) {
+	var tag, kindByte uint8
+	if err := binary.Read(r.r, binary.BigEndian, &tag); err != nil {
+		return Entry{}, err
+	}
+	if err := binary.Read(r.r, binary.BigEndian, &kindByte); err != nil {
+		return Entry{}, err
+	}
+	var bits uint32
+	if err := binary.Read(r.r, binary.BigEndian, &bits); err != nil {
+		return Entry{}, err
+	}
+	var metaLen uint32
+	if err := binary.Read(r.r, binary.BigEndian, &metaLen); err != nil {
+		return Entry{}, err
+	}
+	var metadata []byte
+	if metaLen > 0 {
+		metadata = make([]byte, metaLen)
+		if _, err := io.ReadFull(r.r, metadata); err != nil {
+			return Entry{}, err
+		}
+	}
+	var rawLen uint32
+	if err := binary.Read(r.r, binary.BigEndian, &rawLen); err != nil {
+		return Entry{}, err
+	}
+	raw := make([]byte, rawLen)
+	if _, err := io.ReadFull(r.r, raw); err != nil {
+		return Entry{}, err
+	}
+
+	kind := Kind(kindByte)
+	entry := Entry{Metadata: metadata}
+	switch tag {
+	case recordImageHash:
+		h := &ImageHash{}
+		if err := h.FromByteArr(kind, raw); err != nil {
+			return Entry{}, err
+		}
+		entry.Hash = h
+	case recordExtImageHash:
+		h := &ExtImageHash{}
+		if err := h.FromByteArr(kind, raw); err != nil {
+			return Entry{}, err
+		}
+		entry.ExtHash = h
+	default:
+		return Entry{}, errors.New("goimagehash: unknown Store record type")
+	}
+	return entry, nil
+}
+
+// Store is an in-memory collection of Entry records that can be
+// persisted to and restored from disk with Save and Load. Unlike
+// index.DB, a Store does not require its entries to share a Kind or
+// bit size, since it is a plain container rather than a search index.
+type Store struct {
+	entries []Entry
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Add appends an entry to the Store.
+func (s *Store) Add(e Entry) {
+	s.entries = append(s.entries, e)
+}
+
+// Len returns the number of entries in the Store.
+func (s *Store) Len() int {
+	return len(s.entries)
+}
+
+// Save writes every entry in the Store to w as a Store file.
+func (s *Store) Save(w io.Writer) error {
+	sw := NewWriter(w)
+	if err := sw.WriteHeader(); err != nil {
+		return err
+	}
+	for _, e := range s.entries {
+		if err := sw.Write(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load replaces the Store's contents with the entries read from r.
+func (s *Store) Load(r io.Reader) error {
+	sr := NewReader(r)
+	if err := sr.ReadHeader(); err != nil {
+		return err
+	}
+	var entries []Entry
+	for {
+		e, err := sr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+	s.entries = entries
+	return nil
+}
+
+// Search returns every ImageHash entry already loaded into the Store
+// (via Add or Load) that is within maxHamming of query. It operates
+// entirely in memory; for a Store backed by a file too large to load
+// up front, use SearchStream instead. ExtHash entries are skipped,
+// since Hamming distance is only meaningful between hashes of matching
+// Kind and bit size; for the same reason, an ImageHash entry of a
+// different Kind or bit size than query is skipped rather than failing
+// the whole scan, since a Store (unlike index.DB) is explicitly
+// allowed to hold a mix of them.
+func (s *Store) Search(query *ImageHash, maxHamming int) ([]Entry, error) {
+	var matches []Entry
+	for _, e := range s.entries {
+		if e.Hash == nil {
+			continue
+		}
+		d, err := e.Hash.Distance(query)
+		if err != nil {
+			continue
+		}
+		if d <= maxHamming {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
+
+// SearchStream is the out-of-core counterpart to Search: it scans a
+// Store file read from r one record at a time, never holding more than
+// a single Entry in memory, and returns every ImageHash entry within
+// maxHamming of query. Use it instead of Load followed by Search when
+// the file is too large to load up front; it does not read or modify
+// the receiver's own loaded entries. As with Search, entries that
+// can't be compared to query (ExtHash entries, or an ImageHash of a
+// different Kind or bit size) are skipped rather than failing the scan.
+func (s *Store) SearchStream(r io.Reader, query *ImageHash, maxHamming int) ([]Entry, error) {
+	sr := NewReader(r)
+	if err := sr.ReadHeader(); err != nil {
+		return nil, err
+	}
+	var matches []Entry
+	for {
+		e, err := sr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.Hash == nil {
+			continue
+		}
+		d, err := e.Hash.Distance(query)
+		if err != nil {
+			continue
+		}
+		if d <= maxHamming {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	store := NewStore()
+	store.Add(Entry{Hash: NewImageHash(0x1, AHash), Metadata: []byte("sample1.jpg")})
+	store.Add(Entry{Hash: NewImageHash(0xff00ff00ff00ff00, PHash), Metadata: []byte("sample2.jpg")})
+
+	var buf bytes.Buffer
+	checkErr(store.Save(&buf))
+
+	loaded := NewStore()
+	checkErr(loaded.Load(&buf))
+
+	if loaded.Len() != store.Len() {
+		t.Fatalf("Load got %d entries, want %d", loaded.Len(), store.Len())
+	}
+	for i, e := range loaded.entries {
+		want := store.entries[i]
+		if e.Hash == nil {
+			t.Fatalf("entry %d: Hash is nil", i)
+		}
+		distance, err := e.Hash.Distance(want.Hash)
+		checkErr(err)
+		if distance != 0 {
+			t.Errorf("entry %d: round-tripped hash differs, distance=%v", i, distance)
+		}
+		if string(e.Metadata) != string(want.Metadata) {
+			t.Errorf("entry %d: Metadata = %q, want %q", i, e.Metadata, want.Metadata)
+		}
+	}
+}
+
+func TestWriterReaderAppend(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	checkErr(w.WriteHeader())
+	checkErr(w.Write(Entry{Hash: NewImageHash(0x1, AHash)}))
+
+	// Append a second record onto the same stream after the header.
+	checkErr(w.Write(Entry{Hash: NewImageHash(0x2, AHash)}))
+
+	r := NewReader(&buf)
+	checkErr(r.ReadHeader())
+
+	first, err := r.Read()
+	checkErr(err)
+	if first.Hash == nil || first.Hash.hash != 0x1 {
+		t.Errorf("first entry = %+v, want hash 0x1", first)
+	}
+
+	second, err := r.Read()
+	checkErr(err)
+	if second.Hash == nil || second.Hash.hash != 0x2 {
+		t.Errorf("second entry = %+v, want hash 0x2", second)
+	}
+
+	if _, err := r.Read(); err == nil {
+		t.Errorf("expected io.EOF after the last record")
+	}
+}
+
+func TestStoreSearch(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	store := NewStore()
+	store.Add(Entry{Hash: NewImageHash(0x0, AHash), Metadata: []byte("a")})
+	store.Add(Entry{Hash: NewImageHash(0x1, AHash), Metadata: []byte("b")})
+	store.Add(Entry{Hash: NewImageHash(0xff, AHash), Metadata: []byte("c")})
+
+	query := NewImageHash(0x0, AHash)
+	matches, err := store.Search(query, 1)
+	checkErr(err)
+	if len(matches) != 2 {
+		t.Fatalf("Search returned %d matches, want 2: %+v", len(matches), matches)
+	}
+
+	var buf bytes.Buffer
+	checkErr(store.Save(&buf))
+	streamed, err := store.SearchStream(&buf, query, 1)
+	checkErr(err)
+	if len(streamed) != len(matches) {
+		t.Fatalf("SearchStream returned %d matches, want %d", len(streamed), len(matches))
+	}
+}
+
+// TestStoreSearchMixedKind confirms that, per the Store.Search doc
+// comment, an ImageHash entry of a different Kind than the query and an
+// ExtHash entry don't abort the whole scan; they're simply skipped.
+func TestStoreSearchMixedKind(t *testing.T) {
+	checkErr := func(err error) {
+		if err != nil {
+			t.Errorf("%v", err)
+		}
+	}
+
+	store := NewStore()
+	store.Add(Entry{Hash: NewImageHash(0x0, PHash), Metadata: []byte("different kind")})
+	store.Add(Entry{Hash: NewImageHash(0x1, AHash), Metadata: []byte("match")})
+	store.Add(Entry{ExtHash: NewExtImageHash([]uint64{0x0}, AHash, 64), Metadata: []byte("ext, skipped")})
+
+	query := NewImageHash(0x0, AHash)
+	matches, err := store.Search(query, 1)
+	checkErr(err)
+	if len(matches) != 1 || string(matches[0].Metadata) != "match" {
+		t.Fatalf("Search = %+v, want just the single matching AHash entry", matches)
+	}
+
+	var buf bytes.Buffer
+	checkErr(store.Save(&buf))
+	streamed, err := store.SearchStream(&buf, query, 1)
+	checkErr(err)
+	if len(streamed) != 1 || string(streamed[0].Metadata) != "match" {
+		t.Fatalf("SearchStream = %+v, want just the single matching AHash entry", streamed)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The goimagehash Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goimagehash
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// waveletSize is the low-frequency sub-band WaveletHash keeps; larger
+// bit counts are available through ExtWaveletHash.
+const waveletSize = 8
+
+// WaveletHash computes a perceptual hash using a 2-D Haar discrete
+// wavelet transform: img is grayscaled and reduced, by block-averaging
+// (the low-pass half of the Haar transform), to an 8x8 low-frequency
+// sub-band. Bits are set wherever a sub-band value exceeds the
+// sub-band's median.
+func WaveletHash(img image.Image) (*ImageHash, error) {
+	coeffs := waveletCoefficients(img, waveletSize, waveletSize)
+	median := medianFloat(coeffs)
+
+	var hash uint64
+	for i, v := range coeffs {
+		if v > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return NewImageHash(hash, WHash), nil
+}
+
+// ExtWaveletHash is like WaveletHash but keeps a w x h low-frequency
+// sub-band instead of the fixed 8x8 one, for hashes with more or fewer
+// than 64 bits.
+func ExtWaveletHash(img image.Image, w, h int) (*ExtImageHash, error) {
+	coeffs := waveletCoefficients(img, w, h)
+	median := medianFloat(coeffs)
+
+	bits := w * h
+	words := make([]uint64, (bits+63)/64)
+	for i, v := range coeffs {
+		if v > median {
+			words[i/64] |= 1 << uint(i%64)
+		}
+	}
+	return NewExtImageHash(words, WHash, bits), nil
+}
+
+// waveletCoefficients grayscales img and reduces it to a w x h
+// low-frequency sub-band, returned row-major. Each output coefficient
+// is the average of the source pixels it covers, which is exactly what
+// a 2-D Haar low-pass step converges to; computing that average
+// directly at the target size handles any w and h (not just square,
+// power-of-two ones) without an intermediate grid whose dimensions
+// might never divide evenly down to w x h.
+func waveletCoefficients(img image.Image, w, h int) []float64 {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	coeffs := make([]float64, 0, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := haarBlockRange(bounds.Min.Y, srcH, h, y)
+		for x := 0; x < w; x++ {
+			x0, x1 := haarBlockRange(bounds.Min.X, srcW, w, x)
+
+			var sum float64
+			var n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sum += float64(color.GrayModel.Convert(img.At(sx, sy)).(color.Gray).Y)
+					n++
+				}
+			}
+			coeffs = append(coeffs, sum/float64(n))
+		}
+	}
+	return coeffs
+}
+
+// haarBlockRange returns the [start, end) span of source pixels, along
+// one axis, that output index i covers when srcLen source pixels are
+// divided into dstLen blocks starting at origin. The span always holds
+// at least one pixel, even when dstLen > srcLen.
+func haarBlockRange(origin, srcLen, dstLen, i int) (start, end int) {
+	start = origin + i*srcLen/dstLen
+	end = origin + (i+1)*srcLen/dstLen
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// medianFloat returns the median of values without mutating it.
+func medianFloat(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}